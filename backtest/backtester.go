@@ -0,0 +1,188 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// BuildResult is what a BlockBuilder produces for a single slot.
+type BuildResult struct {
+	Block *types.Block
+
+	UsedBundles      []types.SimulatedBundle
+	DroppedBundles   []DroppedBundle
+	ConflictsAvoided int
+	CoinbaseProfit   *big.Int
+}
+
+// maxPendingTxsPerSender caps how many pending txs runBlock keeps per
+// sender when loading a slot's mempool snapshot. Mempool dump files can be
+// large, and a single misbehaving/spamming sender shouldn't be able to blow
+// up backtest memory usage; cheap to filter at a low ordering cost, since
+// strategies already consume each sender's txs in nonce order.
+const maxPendingTxsPerSender = 1000
+
+// loadPendingTxs reads a slot's pending-tx dump via the streaming
+// PendingTxsFromFileStream and collects it into a per-sender map, keeping at
+// most maxPendingTxsPerSender txs per sender (in file order). Txs beyond the
+// cap are not kept; their count is logged as a warning.
+func loadPendingTxs(filePath string) (map[common.Address]types.Transactions, error) {
+	result := make(map[common.Address]types.Transactions)
+	dropped := 0
+	err := PendingTxsFromFileStream(filePath, func(from common.Address, tx *types.Transaction) error {
+		if len(result[from]) >= maxPendingTxsPerSender {
+			dropped++
+			return nil
+		}
+		result[from] = append(result[from], tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if dropped > 0 {
+		log.Warn("dropped pending txs beyond per-sender cap", "file", filePath, "cap", maxPendingTxsPerSender, "dropped", dropped)
+	}
+	return result, nil
+}
+
+// BlockBuilder is the seam between the backtester and whatever is actually
+// assembling blocks (greedyBuilder, a pluggable miner.BlockBuildingStrategy,
+// ...). Keeping it as an interface lets the backtester replay against any
+// strategy without importing miner's unexported internals.
+type BlockBuilder interface {
+	BuildBlock(parent *types.Header, attrs *types.BuilderPayloadAttributes, simBundles []types.SimulatedBundle, txs map[common.Address]types.Transactions) (*BuildResult, error)
+}
+
+// ChainReader is the subset of ethclient.Client the backtester needs. It's
+// satisfied by *ethclient.Client talking to an archive node, and can be
+// faked out in tests against a local geth datadir.
+type ChainReader interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
+}
+
+// Backtester replays payload building over a range of historical blocks and
+// reports how the builder's output compares to what actually landed
+// on-chain.
+type Backtester struct {
+	chain   ChainReader
+	dataDir string
+	builder BlockBuilder
+}
+
+// NewBacktester dials an archive RPC endpoint and returns a Backtester that
+// loads per-slot inputs from dataDir/<block>/{payload_attr.json,txs,bundles.json}.
+// Use NewBacktesterWithChainReader with a LocalChainReader instead when
+// replaying against a local geth datadir rather than a remote archive node.
+func NewBacktester(archiveRPCURL string, dataDir string, builder BlockBuilder) (*Backtester, error) {
+	client, err := ethclient.Dial(archiveRPCURL)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial archive RPC %s: %w", archiveRPCURL, err)
+	}
+	return NewBacktesterWithChainReader(client, dataDir, builder), nil
+}
+
+// NewBacktesterWithChainReader is like NewBacktester but takes an already
+// constructed ChainReader, e.g. to point the backtester at a local geth
+// datadir opened out-of-process, or a fake in tests.
+func NewBacktesterWithChainReader(chain ChainReader, dataDir string, builder BlockBuilder) *Backtester {
+	return &Backtester{chain: chain, dataDir: dataDir, builder: builder}
+}
+
+// Run replays every block in [startBlock, endBlock], inclusive, and returns
+// one report per block successfully replayed. It keeps going on a per-block
+// error so one bad slot's missing fixtures don't abort the whole range; the
+// error is logged and that block is skipped.
+func (b *Backtester) Run(ctx context.Context, startBlock, endBlock uint64) ([]*BlockReport, error) {
+	if endBlock < startBlock {
+		return nil, fmt.Errorf("endBlock %d is before startBlock %d", endBlock, startBlock)
+	}
+
+	reports := make([]*BlockReport, 0, endBlock-startBlock+1)
+	for blockNum := startBlock; blockNum <= endBlock; blockNum++ {
+		report, err := b.runBlock(ctx, blockNum)
+		if err != nil {
+			log.Error("skipping block in backtest", "block", blockNum, "err", err)
+			continue
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+func (b *Backtester) runBlock(ctx context.Context, blockNum uint64) (*BlockReport, error) {
+	blockDir := filepath.Join(b.dataDir, strconv.FormatUint(blockNum, 10))
+
+	attrs, err := BuilderPayloadAttributesFromFile(filepath.Join(blockDir, "payload_attr.json"))
+	if err != nil {
+		return nil, fmt.Errorf("loading payload attributes: %w", err)
+	}
+
+	txs, err := loadPendingTxs(filepath.Join(blockDir, "txs"))
+	if err != nil {
+		return nil, fmt.Errorf("loading pending txs: %w", err)
+	}
+
+	bundles, err := BundlesFromFile(filepath.Join(blockDir, "bundles.json"))
+	if err != nil {
+		return nil, fmt.Errorf("loading bundles: %w", err)
+	}
+
+	parent, err := b.chain.HeaderByNumber(ctx, new(big.Int).SetUint64(blockNum-1))
+	if err != nil {
+		return nil, fmt.Errorf("fetching parent header %d: %w", blockNum-1, err)
+	}
+
+	result, err := b.builder.BuildBlock(parent, attrs, bundles, txs)
+	if err != nil {
+		return nil, fmt.Errorf("building block %d: %w", blockNum, err)
+	}
+
+	onchain, err := b.chain.BlockByNumber(ctx, new(big.Int).SetUint64(blockNum))
+	if err != nil {
+		return nil, fmt.Errorf("fetching on-chain block %d: %w", blockNum, err)
+	}
+
+	missed, misordered := diffBlocks(result.Block, onchain)
+
+	return &BlockReport{
+		BlockNumber:       blockNum,
+		CoinbaseProfitWei: result.CoinbaseProfit.String(),
+		GasUsed:           result.Block.GasUsed(),
+		BundlesIncluded:   len(result.UsedBundles),
+		BundlesDropped:    result.DroppedBundles,
+		ConflictsAvoided:  result.ConflictsAvoided,
+		MissedTxs:         missed,
+		MisorderedTxs:     misordered,
+	}, nil
+}
+
+// diffBlocks reports which on-chain txs the built block is missing, and
+// which txs landed in both blocks but at a different position.
+func diffBlocks(built, onchain *types.Block) (missed, misordered []common.Hash) {
+	builtIndex := make(map[common.Hash]int, len(built.Transactions()))
+	for i, tx := range built.Transactions() {
+		builtIndex[tx.Hash()] = i
+	}
+
+	for i, tx := range onchain.Transactions() {
+		builtAt, ok := builtIndex[tx.Hash()]
+		if !ok {
+			missed = append(missed, tx.Hash())
+			continue
+		}
+		if builtAt != i {
+			misordered = append(misordered, tx.Hash())
+		}
+	}
+	return missed, misordered
+}
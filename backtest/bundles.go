@@ -0,0 +1,26 @@
+package backtest
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// BundlesFromFile loads a slot's bundle set from a bundles.json file. The
+// file holds an already-simulated bundle set, in the same shape
+// types.SimulatedBundle serializes to, so the backtester can feed it
+// straight into a builder strategy without re-simulating.
+func BundlesFromFile(filePath string) ([]types.SimulatedBundle, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var bundles []types.SimulatedBundle
+	if err := json.NewDecoder(f).Decode(&bundles); err != nil {
+		return nil, err
+	}
+	return bundles, nil
+}
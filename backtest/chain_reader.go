@@ -0,0 +1,33 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// LocalChainReader adapts a *core.BlockChain opened against a local geth
+// datadir to the ChainReader interface, so the backtester can replay slots
+// without an archive RPC endpoint at all.
+type LocalChainReader struct {
+	Chain *core.BlockChain
+}
+
+func (r *LocalChainReader) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header := r.Chain.GetHeaderByNumber(number.Uint64())
+	if header == nil {
+		return nil, fmt.Errorf("header %s not found in local chain", number)
+	}
+	return header, nil
+}
+
+func (r *LocalChainReader) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	block := r.Chain.GetBlockByNumber(number.Uint64())
+	if block == nil {
+		return nil, fmt.Errorf("block %s not found in local chain", number)
+	}
+	return block, nil
+}
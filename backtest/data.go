@@ -57,3 +57,38 @@ func PendingTxsFromFile(
 
 	return result, nil
 }
+
+// PendingTxFn is called once per pending tx found by PendingTxsFromFileStream.
+type PendingTxFn func(from common.Address, tx *types.Transaction) error
+
+// PendingTxsFromFileStream is the streaming equivalent of PendingTxsFromFile.
+// Instead of buffering every tx into a map, it invokes fn as each line is
+// read, so callers replaying a slot with a large mempool snapshot don't have
+// to hold it all in memory at once.
+func PendingTxsFromFileStream(filePath string, fn PendingTxFn) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fileScanner := bufio.NewScanner(f)
+	for fileScanner.Scan() {
+		line := fileScanner.Text()
+		splitLine := strings.SplitN(line, " ", 2)
+		if len(splitLine) != 2 {
+			continue
+		}
+		fromString, txString := splitLine[0], splitLine[1]
+		from := common.HexToAddress(fromString)
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalJSON([]byte(txString)); err != nil {
+			return err
+		}
+		if err := fn(from, tx); err != nil {
+			return err
+		}
+	}
+
+	return fileScanner.Err()
+}
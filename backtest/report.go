@@ -0,0 +1,82 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// DroppedBundle records a bundle the builder didn't include, and why.
+type DroppedBundle struct {
+	Hash   common.Hash `json:"hash"`
+	Reason string      `json:"reason"`
+}
+
+// BlockReport is the per-block result of replaying payload building against
+// a historical slot.
+type BlockReport struct {
+	BlockNumber uint64 `json:"blockNumber"`
+
+	CoinbaseProfitWei string `json:"coinbaseProfitWei"`
+	GasUsed           uint64 `json:"gasUsed"`
+
+	BundlesIncluded  int             `json:"bundlesIncluded"`
+	BundlesDropped   []DroppedBundle `json:"bundlesDropped"`
+	ConflictsAvoided int             `json:"conflictsAvoided"`
+
+	// MissedTxs are on-chain txs the builder's block didn't include.
+	MissedTxs []common.Hash `json:"missedTxs"`
+	// MisorderedTxs are txs present in both blocks but at a different index.
+	MisorderedTxs []common.Hash `json:"misorderedTxs"`
+}
+
+// WriteReportsJSON writes the full per-block report set as a JSON array.
+func WriteReportsJSON(path string, reports []*BlockReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
+
+// WriteReportsCSV writes the aggregate, one-row-per-block summary suitable
+// for plotting coinbase profit / gas used / bundle hit-rate over a range.
+func WriteReportsCSV(path string, reports []*BlockReport) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	defer w.Flush()
+
+	header := []string{"block_number", "coinbase_profit_wei", "gas_used", "bundles_included", "bundles_dropped", "conflicts_avoided", "missed_txs", "misordered_txs"}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		row := []string{
+			fmt.Sprintf("%d", r.BlockNumber),
+			r.CoinbaseProfitWei,
+			fmt.Sprintf("%d", r.GasUsed),
+			fmt.Sprintf("%d", r.BundlesIncluded),
+			fmt.Sprintf("%d", len(r.BundlesDropped)),
+			fmt.Sprintf("%d", r.ConflictsAvoided),
+			fmt.Sprintf("%d", len(r.MissedTxs)),
+			fmt.Sprintf("%d", len(r.MisorderedTxs)),
+		}
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package backtest
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/flashbots/builder/miner"
+)
+
+// StrategyBuilder adapts miner.BuildBlockForBacktest to the BlockBuilder
+// interface, so Backtester can drive the live builder's strategies
+// (greedy, profit-backtracking, ...) against historical slots.
+type StrategyBuilder struct {
+	Chain       *core.BlockChain
+	ChainConfig *params.ChainConfig
+	Blacklist   map[common.Address]struct{}
+	Strategy    miner.BuilderStrategyName
+}
+
+func (b *StrategyBuilder) BuildBlock(parent *types.Header, attrs *types.BuilderPayloadAttributes, simBundles []types.SimulatedBundle, txs map[common.Address]types.Transactions) (*BuildResult, error) {
+	block, usedBundles, conflictDropped, profit, err := miner.BuildBlockForBacktest(b.Chain, b.ChainConfig, b.Blacklist, b.Strategy, parent, attrs, simBundles, txs)
+	if err != nil {
+		return nil, err
+	}
+
+	conflictHashes := make(map[common.Hash]struct{}, len(conflictDropped))
+	for _, bundle := range conflictDropped {
+		conflictHashes[bundle.OriginalBundle.Hash] = struct{}{}
+	}
+
+	dropped := make([]DroppedBundle, 0)
+	for _, bundle := range miner.DroppedBundles(simBundles, usedBundles) {
+		reason := "not included by builder"
+		if _, ok := conflictHashes[bundle.OriginalBundle.Hash]; ok {
+			reason = "excluded: conflicts with a higher-value bundle in the same block"
+		}
+		dropped = append(dropped, DroppedBundle{Hash: bundle.OriginalBundle.Hash, Reason: reason})
+	}
+
+	return &BuildResult{
+		Block:            block,
+		UsedBundles:      usedBundles,
+		DroppedBundles:   dropped,
+		ConflictsAvoided: len(conflictDropped),
+		CoinbaseProfit:   profit,
+	}, nil
+}
@@ -19,11 +19,25 @@ import (
 
 var ErrValidatorNotFound = errors.New("validator not found")
 
+// HTTPStatusError wraps a non-2xx relay response so callers that only see
+// the returned error (e.g. RelaySet's per-relay metrics) can still tell a
+// client-side rejection (4xx) apart from a relay-side failure (5xx) without
+// re-parsing the error string.
+type HTTPStatusError struct {
+	Code     int
+	Endpoint string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("non-ok response code %d from relay %s", e.Code, e.Endpoint)
+}
+
 type RemoteRelay struct {
 	endpoint string
 	client   http.Client
 
 	localRelay *LocalRelay
+	limiter    *BlockSubmissionRateLimiter
 
 	validatorsLock       sync.RWMutex
 	validatorSyncOngoing bool
@@ -127,6 +141,14 @@ func (r *RemoteRelay) GetValidatorForSlot(nextSlot uint64) (ValidatorData, error
 	return ValidatorData{}, ErrValidatorNotFound
 }
 
+// currentSlot returns the last slot a validators-map refresh was requested
+// for, guarded the same way every other access to lastRequestedSlot is.
+func (r *RemoteRelay) currentSlot() uint64 {
+	r.validatorsLock.RLock()
+	defer r.validatorsLock.RUnlock()
+	return r.lastRequestedSlot
+}
+
 func (r *RemoteRelay) Start() error {
 	return nil
 }
@@ -175,13 +197,18 @@ func (r *RemoteRelay) GetHeader(slot uint64, parentHashHex string, pubkey string
 }
 
 func (r *RemoteRelay) SubmitBlock(msg *boostTypes.BuilderSubmitBlockRequest, _ ValidatorData) error {
+	if !r.waitForSubmissionSlot(msg.Message.Slot, msg.Message.Value.BigInt()) {
+		log.Info("dropping stale block submission due to rate limit", "endpoint", r.endpoint, "slot", msg.Message.Slot)
+		return nil
+	}
+
 	log.Info("submitting block to remote relay", "endpoint", r.endpoint)
 	code, err := server.SendHTTPRequest(context.TODO(), *http.DefaultClient, http.MethodPost, r.endpoint+"/relay/v1/builder/blocks", msg, nil)
 	if err != nil {
 		return fmt.Errorf("error sending http request to relay %s. err: %w", r.endpoint, err)
 	}
 	if code > 299 {
-		return fmt.Errorf("non-ok response code %d from relay %s", code, r.endpoint)
+		return &HTTPStatusError{Code: code, Endpoint: r.endpoint}
 	}
 
 	if r.localRelay != nil {
@@ -192,13 +219,18 @@ func (r *RemoteRelay) SubmitBlock(msg *boostTypes.BuilderSubmitBlockRequest, _ V
 }
 
 func (r *RemoteRelay) SubmitBlockCapella(msg *capella.SubmitBlockRequest, _ ValidatorData) error {
+	if !r.waitForSubmissionSlot(msg.Message.Slot, msg.Message.Value.ToBig()) {
+		log.Info("dropping stale block submission due to rate limit", "endpoint", r.endpoint, "slot", msg.Message.Slot)
+		return nil
+	}
+
 	log.Info("submitting block to remote relay", "endpoint", r.endpoint)
 	code, err := server.SendHTTPRequest(context.TODO(), *http.DefaultClient, http.MethodPost, r.endpoint+"/relay/v1/builder/blocks", msg, nil)
 	if err != nil {
 		return fmt.Errorf("error sending http request to relay %s. err: %w", r.endpoint, err)
 	}
 	if code > 299 {
-		return fmt.Errorf("non-ok response code %d from relay %s", code, r.endpoint)
+		return &HTTPStatusError{Code: code, Endpoint: r.endpoint}
 	}
 
 	if r.localRelay != nil {
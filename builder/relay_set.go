@@ -0,0 +1,350 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/attestantio/go-builder-client/api/capella"
+	"github.com/ethereum/go-ethereum/log"
+	boostTypes "github.com/flashbots/go-boost-utils/types"
+)
+
+// IRelay is the interface both a single RemoteRelay and a RelaySet satisfy,
+// so the builder loop doesn't need to care whether it's talking to one relay
+// or fanning out to several.
+type IRelay interface {
+	SubmitBlock(msg *boostTypes.BuilderSubmitBlockRequest, vd ValidatorData) error
+	SubmitBlockCapella(msg *capella.SubmitBlockRequest, vd ValidatorData) error
+	GetValidatorForSlot(nextSlot uint64) (ValidatorData, error)
+	Start() error
+	Stop()
+}
+
+var (
+	_ IRelay = (*RemoteRelay)(nil)
+	_ IRelay = (*RelaySet)(nil)
+)
+
+// RelayConfig describes one relay in a RelaySet.
+type RelayConfig struct {
+	Endpoint string
+	Timeout  time.Duration
+
+	// RequiredValidatorPubkey, if set, pins this entry to a specific
+	// validator registration pubkey: GetValidatorForSlot ignores any
+	// ValidatorData this relay returns whose Pubkey doesn't match. This is
+	// not a signature check against the relay's own identity - the relay's
+	// registration response is trusted as-is.
+	RequiredValidatorPubkey boostTypes.PublicKey
+
+	// Optional relays may fail SubmitBlock/SubmitBlockCapella without
+	// failing the overall submission.
+	Optional bool
+
+	// MinSubmissionInterval and MaxSubmissionsPerSlot configure this entry's
+	// BlockSubmissionRateLimiter. MinSubmissionInterval <= 0 (the default)
+	// leaves submissions to this relay unthrottled; MaxSubmissionsPerSlot <= 0
+	// means no per-slot cap.
+	MinSubmissionInterval time.Duration
+	MaxSubmissionsPerSlot int
+}
+
+// latencyBucketsMs are the upper bounds (in milliseconds) of the submission
+// latency histogram buckets; an extra overflow bucket catches everything
+// above the largest bound.
+var latencyBucketsMs = [...]int64{10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+// RelayMetrics tracks simple per-relay counters. It intentionally avoids
+// pulling in a metrics client library so it has no extra dependencies.
+type RelayMetrics struct {
+	Success int64
+	Err4xx  int64
+	Err5xx  int64
+
+	// latencyBuckets[i] counts submissions whose latency was <= latencyBucketsMs[i]
+	// and above latencyBucketsMs[i-1] (buckets are not cumulative); the last
+	// element counts everything above the largest bound.
+	latencyBuckets [len(latencyBucketsMs) + 1]int64
+}
+
+func (m *RelayMetrics) recordLatency(d time.Duration) {
+	ms := d.Milliseconds()
+	idx := len(latencyBucketsMs)
+	for i, bound := range latencyBucketsMs {
+		if ms <= bound {
+			idx = i
+			break
+		}
+	}
+	atomic.AddInt64(&m.latencyBuckets[idx], 1)
+}
+
+// LatencyHistogram returns submission counts per latency bucket, keyed by
+// each bucket's upper bound in milliseconds ("+Inf" for the overflow bucket).
+// Each submission is counted in exactly one bucket.
+func (m *RelayMetrics) LatencyHistogram() map[string]int64 {
+	out := make(map[string]int64, len(latencyBucketsMs)+1)
+	for i, bound := range latencyBucketsMs {
+		out[fmt.Sprintf("<=%dms", bound)] = atomic.LoadInt64(&m.latencyBuckets[i])
+	}
+	out["+Inf"] = atomic.LoadInt64(&m.latencyBuckets[len(latencyBucketsMs)])
+	return out
+}
+
+type relayEntry struct {
+	config  RelayConfig
+	relay   *RemoteRelay
+	metrics *RelayMetrics
+	limiter *BlockSubmissionRateLimiter
+}
+
+// RelaySet fans a block out to several relays concurrently, in priority
+// order (the order the RelayConfigs were given in), applying per-relay
+// timeouts and trust/optionality policy.
+type RelaySet struct {
+	entries []*relayEntry
+
+	refreshStop chan struct{}
+	refreshWg   sync.WaitGroup
+}
+
+// NewRelaySet builds a RelaySet from a prioritized list of relay configs.
+// localRelay, if non-nil, overlays locally-submitted validator registrations
+// on top of every relay in the set, same as a single RemoteRelay would. An
+// entry whose MinSubmissionInterval is set gets its own
+// BlockSubmissionRateLimiter, started and stopped alongside the rest of the
+// set by RelaySet.Start/Stop.
+func NewRelaySet(configs []RelayConfig, localRelay *LocalRelay) *RelaySet {
+	rs := &RelaySet{}
+	for _, cfg := range configs {
+		r := NewRemoteRelay(cfg.Endpoint, localRelay)
+		if cfg.Timeout > 0 {
+			r.client.Timeout = cfg.Timeout
+		}
+
+		var limiter *BlockSubmissionRateLimiter
+		if cfg.MinSubmissionInterval > 0 {
+			limiter = NewBlockSubmissionRateLimiter(cfg.MinSubmissionInterval, cfg.MaxSubmissionsPerSlot)
+			r.SetSubmissionRateLimiter(limiter)
+		}
+
+		rs.entries = append(rs.entries, &relayEntry{
+			config:  cfg,
+			relay:   r,
+			metrics: &RelayMetrics{},
+			limiter: limiter,
+		})
+	}
+	return rs
+}
+
+// Metrics returns the per-relay metrics, keyed by endpoint.
+func (rs *RelaySet) Metrics() map[string]*RelayMetrics {
+	out := make(map[string]*RelayMetrics, len(rs.entries))
+	for _, e := range rs.entries {
+		out[e.config.Endpoint] = e.metrics
+	}
+	return out
+}
+
+// GetValidatorForSlot consults relays in priority order and returns the
+// first result whose Pubkey matches RequiredValidatorPubkey (when one is
+// configured for that entry).
+func (rs *RelaySet) GetValidatorForSlot(nextSlot uint64) (ValidatorData, error) {
+	var lastErr error = ErrValidatorNotFound
+	for _, e := range rs.entries {
+		vd, err := e.relay.GetValidatorForSlot(nextSlot)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var zero boostTypes.PublicKey
+		if e.config.RequiredValidatorPubkey != zero && vd.Pubkey != PubkeyHex(strings.ToLower(e.config.RequiredValidatorPubkey.String())) {
+			log.Warn("validator data from relay did not match required validator pubkey, skipping", "endpoint", e.config.Endpoint, "slot", nextSlot)
+			continue
+		}
+		return vd, nil
+	}
+	return ValidatorData{}, lastErr
+}
+
+type submitResult struct {
+	entry *relayEntry
+	err   error
+}
+
+// SubmitBlock fans the block out to every relay concurrently. It returns nil
+// if any non-optional relay accepted the block (or, when every relay in the
+// set is optional, if any relay at all accepted it). Otherwise it returns the
+// first non-optional relay's error received, or a generic error if no
+// non-optional relay reported one.
+func (rs *RelaySet) SubmitBlock(msg *boostTypes.BuilderSubmitBlockRequest, vd ValidatorData) error {
+	return rs.submit(func(e *relayEntry) error {
+		return e.relay.SubmitBlock(msg, vd)
+	})
+}
+
+// SubmitBlockCapella is the capella-schema equivalent of SubmitBlock.
+func (rs *RelaySet) SubmitBlockCapella(msg *capella.SubmitBlockRequest, vd ValidatorData) error {
+	return rs.submit(func(e *relayEntry) error {
+		return e.relay.SubmitBlockCapella(msg, vd)
+	})
+}
+
+func (rs *RelaySet) submit(do func(e *relayEntry) error) error {
+	results := make(chan submitResult, len(rs.entries))
+
+	for _, e := range rs.entries {
+		e := e
+		go func() {
+			timeout := e.config.Timeout
+			if timeout <= 0 {
+				timeout = 2 * time.Second
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			errCh := make(chan error, 1)
+			go func() { errCh <- do(e) }()
+
+			var err error
+			select {
+			case err = <-errCh:
+			case <-ctx.Done():
+				err = fmt.Errorf("relay %s timed out after %s", e.config.Endpoint, timeout)
+			}
+			e.metrics.recordLatency(time.Since(start))
+			recordRelayResult(e.metrics, err)
+
+			results <- submitResult{entry: e, err: err}
+		}()
+	}
+
+	var (
+		firstErr         error
+		hasRequired      bool
+		requiredAccepted bool
+		anyAccepted      bool
+	)
+	for range rs.entries {
+		res := <-results
+		if !res.entry.config.Optional {
+			hasRequired = true
+		}
+		if res.err == nil {
+			anyAccepted = true
+			if !res.entry.config.Optional {
+				requiredAccepted = true
+			}
+			continue
+		}
+		log.Warn("relay submission failed", "endpoint", res.entry.config.Endpoint, "optional", res.entry.config.Optional, "err", res.err)
+		if !res.entry.config.Optional && firstErr == nil {
+			firstErr = res.err
+		}
+	}
+
+	// Success if any non-optional relay accepted the block; if the set is
+	// entirely optional relays, fall back to any acceptance at all.
+	if requiredAccepted || (!hasRequired && anyAccepted) {
+		return nil
+	}
+	if firstErr != nil {
+		return firstErr
+	}
+	return fmt.Errorf("no relay accepted the block")
+}
+
+func recordRelayResult(m *RelayMetrics, err error) {
+	if err == nil {
+		atomic.AddInt64(&m.Success, 1)
+		return
+	}
+	// A 4xx means the relay rejected the block itself; everything else
+	// (timeouts, transport errors, 5xx) is bucketed as Err5xx.
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && statusErr.Code >= 400 && statusErr.Code < 500 {
+		atomic.AddInt64(&m.Err4xx, 1)
+		return
+	}
+	atomic.AddInt64(&m.Err5xx, 1)
+}
+
+// Start kicks off every relay's background sync and rate limiter (if one is
+// configured), plus a refresher that staggers validator-map refreshes across
+// relays, so they don't all hit their relay at once.
+func (rs *RelaySet) Start() error {
+	for _, e := range rs.entries {
+		if err := e.relay.Start(); err != nil {
+			return err
+		}
+		if e.limiter != nil {
+			e.limiter.Start()
+		}
+	}
+
+	rs.refreshStop = make(chan struct{})
+	rs.refreshWg.Add(1)
+	go rs.refreshLoop()
+	return nil
+}
+
+func (rs *RelaySet) refreshLoop() {
+	defer rs.refreshWg.Done()
+
+	const refreshInterval = 12 * time.Second
+	for i, e := range rs.entries {
+		i, e := i, e
+		rs.refreshWg.Add(1)
+		go func() {
+			defer rs.refreshWg.Done()
+
+			// Stagger the first refresh per relay so N relays don't all
+			// issue their validators-map request in the same instant. Each
+			// entry waits independently, so relay N's offset doesn't add to
+			// relay N-1's.
+			offset := time.Duration(i) * refreshInterval / time.Duration(len(rs.entries))
+			select {
+			case <-time.After(offset):
+			case <-rs.refreshStop:
+				return
+			}
+
+			jitter := time.Duration(rand.Int63n(int64(refreshInterval)))
+			ticker := time.NewTicker(refreshInterval + jitter)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					if err := e.relay.updateValidatorsMap(e.relay.currentSlot(), 1); err != nil {
+						log.Error("could not refresh validators map", "endpoint", e.config.Endpoint, "err", err)
+					}
+				case <-rs.refreshStop:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// Stop stops every relay, its rate limiter (if any), and the background
+// refresher.
+func (rs *RelaySet) Stop() {
+	if rs.refreshStop != nil {
+		close(rs.refreshStop)
+	}
+	for _, e := range rs.entries {
+		e.relay.Stop()
+		if e.limiter != nil {
+			e.limiter.Stop()
+		}
+	}
+	rs.refreshWg.Wait()
+}
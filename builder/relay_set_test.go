@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRelaySetSubmitSucceedsIfAnyRequiredRelayAccepts(t *testing.T) {
+	rs := &RelaySet{entries: []*relayEntry{
+		{config: RelayConfig{Endpoint: "required-ok"}, metrics: &RelayMetrics{}},
+		{config: RelayConfig{Endpoint: "required-fail"}, metrics: &RelayMetrics{}},
+	}}
+
+	err := rs.submit(func(e *relayEntry) error {
+		if e.config.Endpoint == "required-fail" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected nil error when at least one non-optional relay accepted, got %v", err)
+	}
+}
+
+func TestRelaySetSubmitFailsIfAllRequiredRelaysFail(t *testing.T) {
+	rs := &RelaySet{entries: []*relayEntry{
+		{config: RelayConfig{Endpoint: "required"}, metrics: &RelayMetrics{}},
+		{config: RelayConfig{Endpoint: "optional", Optional: true}, metrics: &RelayMetrics{}},
+	}}
+
+	err := rs.submit(func(e *relayEntry) error {
+		return errors.New(e.config.Endpoint + " failed")
+	})
+	if err == nil {
+		t.Error("expected an error when the only non-optional relay failed, even though an optional relay also failed")
+	}
+}
+
+func TestRelaySetSubmitAllOptionalFallsBackToAnyAcceptance(t *testing.T) {
+	rs := &RelaySet{entries: []*relayEntry{
+		{config: RelayConfig{Endpoint: "a", Optional: true}, metrics: &RelayMetrics{}},
+		{config: RelayConfig{Endpoint: "b", Optional: true}, metrics: &RelayMetrics{}},
+	}}
+
+	err := rs.submit(func(e *relayEntry) error {
+		if e.config.Endpoint == "a" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("expected nil when any relay accepted in an all-optional set, got %v", err)
+	}
+}
+
+func TestRecordRelayResultClassifiesStatusCode(t *testing.T) {
+	m := &RelayMetrics{}
+	recordRelayResult(m, nil)
+	recordRelayResult(m, &HTTPStatusError{Code: 400, Endpoint: "x"})
+	recordRelayResult(m, &HTTPStatusError{Code: 503, Endpoint: "x"})
+	recordRelayResult(m, errors.New("some transport error"))
+
+	if m.Success != 1 {
+		t.Errorf("expected 1 Success, got %d", m.Success)
+	}
+	if m.Err4xx != 1 {
+		t.Errorf("expected 1 Err4xx, got %d", m.Err4xx)
+	}
+	if m.Err5xx != 2 {
+		t.Errorf("expected 2 Err5xx (one real 5xx, one unclassified error), got %d", m.Err5xx)
+	}
+}
@@ -0,0 +1,180 @@
+package builder
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"time"
+)
+
+// BlockSubmissionRateLimiter throttles how often blocks are pushed to a relay.
+// Builders commonly re-build several times per slot as better bundles/txs
+// arrive, but relays rate-limit (and sometimes penalize) overly frequent
+// submissions. Rather than submitting every candidate, callers funnel
+// candidates through Limit and only the highest-value one seen within
+// minInterval is actually forwarded; the rest are dropped.
+type BlockSubmissionRateLimiter struct {
+	minInterval           time.Duration
+	maxSubmissionsPerSlot int
+
+	submissionsCh chan *rateLimitedSubmission
+
+	// mu guards stopped, and is held for the full duration of a Limit call's
+	// attempt to enqueue, so that Stop can't mark the limiter stopped between
+	// a Limit call checking stopped and it actually enqueueing: whichever one
+	// gets the lock first is linearized before the other.
+	mu      sync.Mutex
+	stopped bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+type rateLimitedSubmission struct {
+	slot     uint64
+	value    *big.Int
+	resultCh chan bool
+}
+
+// NewBlockSubmissionRateLimiter creates a limiter that forwards at most one
+// submission per minInterval, and at most maxSubmissionsPerSlot submissions
+// per slot. maxSubmissionsPerSlot <= 0 means unlimited.
+func NewBlockSubmissionRateLimiter(minInterval time.Duration, maxSubmissionsPerSlot int) *BlockSubmissionRateLimiter {
+	return &BlockSubmissionRateLimiter{
+		minInterval:           minInterval,
+		maxSubmissionsPerSlot: maxSubmissionsPerSlot,
+		submissionsCh:         make(chan *rateLimitedSubmission, 32),
+	}
+}
+
+// Start launches the background goroutine that arbitrates submissions. It is
+// safe to call Limit before Start: the loop isn't draining submissionsCh yet,
+// so submissions simply sit in its buffer until Start runs.
+func (l *BlockSubmissionRateLimiter) Start() {
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+	go l.loop()
+}
+
+// Stop cancels the limiter's context, causing any in-flight or future Limit
+// calls to unblock with false.
+func (l *BlockSubmissionRateLimiter) Stop() {
+	l.mu.Lock()
+	l.stopped = true
+	l.mu.Unlock()
+
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// Limit submits a candidate block for a given slot and value, and returns a
+// channel that receives true if it should be submitted to the relay, or
+// false if a better or newer candidate superseded it, or the limiter was
+// stopped.
+func (l *BlockSubmissionRateLimiter) Limit(slot uint64, value *big.Int) <-chan bool {
+	resultCh := make(chan bool, 1)
+	sub := &rateLimitedSubmission{slot: slot, value: value, resultCh: resultCh}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		resultCh <- false
+		return resultCh
+	}
+
+	// Not stopped, and Stop can't flip that until it acquires mu, which it
+	// can't do until this send (buffered, so it only blocks while the
+	// channel's buffer is full and loop() isn't draining it) completes. So
+	// this submission is guaranteed to
+	// either be drained by loop(), or be sitting in submissionsCh by the time
+	// Stop's subsequent cancel() fires loop's drain-on-shutdown below.
+	l.submissionsCh <- sub
+	return resultCh
+}
+
+func (l *BlockSubmissionRateLimiter) loop() {
+	var (
+		currentSlot uint64
+		best        *rateLimitedSubmission
+		pending     []*rateLimitedSubmission
+		sentInSlot  int
+	)
+
+	timer := time.NewTimer(l.minInterval)
+	defer timer.Stop()
+
+	flush := func() {
+		for _, sub := range pending {
+			if sub != best {
+				sub.resultCh <- false
+			}
+		}
+		if best != nil {
+			best.resultCh <- true
+			sentInSlot++
+		}
+		pending = pending[:0]
+		best = nil
+	}
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			for _, sub := range pending {
+				sub.resultCh <- false
+			}
+			// Stop() guarantees no further sends to submissionsCh will start
+			// after this point, but one may already be queued in its buffer
+			// (enqueued under the same lock Stop needed before cancelling).
+			// Drain it so that caller isn't left blocked forever.
+			for {
+				select {
+				case sub := <-l.submissionsCh:
+					sub.resultCh <- false
+				default:
+					return
+				}
+			}
+		case sub := <-l.submissionsCh:
+			if sub.slot != currentSlot {
+				flush()
+				currentSlot = sub.slot
+				sentInSlot = 0
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(l.minInterval)
+			}
+
+			if l.maxSubmissionsPerSlot > 0 && sentInSlot >= l.maxSubmissionsPerSlot {
+				sub.resultCh <- false
+				continue
+			}
+
+			pending = append(pending, sub)
+			if best == nil || sub.value.Cmp(best.value) > 0 {
+				best = sub
+			}
+		case <-timer.C:
+			flush()
+			timer.Reset(l.minInterval)
+		}
+	}
+}
+
+// SetSubmissionRateLimiter attaches a BlockSubmissionRateLimiter to the relay.
+// When set, SubmitBlock and SubmitBlockCapella only forward the highest-value
+// block seen within the limiter's window for a given slot, dropping stale
+// ones instead of sending them to the relay.
+func (r *RemoteRelay) SetSubmissionRateLimiter(limiter *BlockSubmissionRateLimiter) {
+	r.limiter = limiter
+}
+
+// waitForSubmissionSlot blocks on the rate limiter, if one is configured, and
+// reports whether this submission should proceed.
+func (r *RemoteRelay) waitForSubmissionSlot(slot uint64, value *big.Int) bool {
+	if r.limiter == nil {
+		return true
+	}
+	return <-r.limiter.Limit(slot, value)
+}
@@ -0,0 +1,90 @@
+package builder
+
+import (
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestBlockSubmissionRateLimiterForwardsHighestValue(t *testing.T) {
+	l := NewBlockSubmissionRateLimiter(50*time.Millisecond, 0)
+	l.Start()
+	defer l.Stop()
+
+	lowCh := l.Limit(1, big.NewInt(10))
+	highCh := l.Limit(1, big.NewInt(20))
+
+	if ok := <-lowCh; ok {
+		t.Error("expected lower-value submission to be dropped")
+	}
+	if ok := <-highCh; !ok {
+		t.Error("expected higher-value submission to be forwarded")
+	}
+}
+
+func TestBlockSubmissionRateLimiterNewSlotFlushesPrevious(t *testing.T) {
+	l := NewBlockSubmissionRateLimiter(time.Hour, 0)
+	l.Start()
+	defer l.Stop()
+
+	slot1Ch := l.Limit(1, big.NewInt(10))
+	slot2Ch := l.Limit(2, big.NewInt(1))
+
+	if ok := <-slot1Ch; !ok {
+		t.Error("expected slot 1's only submission to be forwarded once slot 2 starts")
+	}
+	// slot2Ch isn't resolved yet: it's the new best of a slot whose window
+	// hasn't elapsed. Stop() below will flush it as dropped.
+	select {
+	case <-slot2Ch:
+		t.Error("slot 2 submission resolved before its window elapsed or the limiter stopped")
+	default:
+	}
+}
+
+func TestBlockSubmissionRateLimiterMaxSubmissionsPerSlot(t *testing.T) {
+	l := NewBlockSubmissionRateLimiter(10*time.Millisecond, 1)
+	l.Start()
+	defer l.Stop()
+
+	first := l.Limit(1, big.NewInt(1))
+	if ok := <-first; !ok {
+		t.Fatal("expected first submission in slot to be forwarded")
+	}
+
+	second := l.Limit(1, big.NewInt(100))
+	select {
+	case ok := <-second:
+		if ok {
+			t.Error("expected submission beyond per-slot cap to be dropped")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for over-cap submission to resolve")
+	}
+}
+
+func TestBlockSubmissionRateLimiterStopUnblocksInFlightAndFutureCalls(t *testing.T) {
+	l := NewBlockSubmissionRateLimiter(time.Hour, 0)
+	l.Start()
+
+	inFlight := l.Limit(1, big.NewInt(1))
+	l.Stop()
+
+	select {
+	case ok := <-inFlight:
+		if ok {
+			t.Error("expected in-flight submission to be dropped on Stop")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for in-flight submission to resolve after Stop")
+	}
+
+	select {
+	case ok := <-l.Limit(1, big.NewInt(1)):
+		if ok {
+			t.Error("expected post-Stop submission to be dropped immediately")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for post-Stop submission to resolve")
+	}
+}
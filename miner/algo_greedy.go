@@ -82,6 +82,7 @@ func (b *greedyBuilder) mergeOrdersIntoEnvDiff(envDiff *environmentDiff, orders
 }
 
 func (b *greedyBuilder) buildBlock(simBundles []types.SimulatedBundle, transactions map[common.Address]types.Transactions) (*environment, []types.SimulatedBundle) {
+	simBundles, _ = AnalyzeBundleConflicts(simBundles, b.inputEnvironment, b.chainData)
 	orders := types.NewTransactionsByPriceAndNonce(b.inputEnvironment.signer, transactions, simBundles, b.inputEnvironment.header.BaseFee)
 	envDiff := newEnvironmentDiff(b.inputEnvironment.copy())
 	usedBundles := b.mergeOrdersIntoEnvDiff(envDiff, orders)
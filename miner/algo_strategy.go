@@ -0,0 +1,225 @@
+package miner
+
+import (
+	"math/big"
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BlockBuildingStrategy picks an ordering of bundles and mempool transactions
+// to fill a block with. greedyBuilder is the strategy used historically;
+// profitBacktrackingStrategy is an alternative that re-checks bundle
+// profitability as later bundles land.
+type BlockBuildingStrategy interface {
+	BuildBlock(env *environment, bundles []types.SimulatedBundle, txs map[common.Address]types.Transactions) (*environment, []types.SimulatedBundle)
+}
+
+// BuilderStrategyName selects a BlockBuildingStrategy from config.
+//
+// Only BuildBlockForBacktest (and the backtest CLI's -strategy flag) picks a
+// BlockBuildingStrategy by name today; the live worker's block-building
+// entrypoint isn't part of this package and still always runs greedyBuilder
+// directly. Wiring a config flag through that entrypoint is tracked
+// separately rather than done here, since it isn't reachable from this
+// package.
+type BuilderStrategyName string
+
+const (
+	// StrategyGreedy is the original, price/nonce + EGP-ordered strategy.
+	StrategyGreedy BuilderStrategyName = "greedy"
+	// StrategyProfitBacktracking rolls back bundles that become
+	// unprofitable after earlier inclusions.
+	StrategyProfitBacktracking BuilderStrategyName = "profit-backtracking"
+	// StrategyRace runs every known strategy for the slot and keeps
+	// whichever produces the most profitable block.
+	StrategyRace BuilderStrategyName = "race"
+)
+
+// newBlockBuildingStrategy constructs the strategy selected by name, falling
+// back to the greedy strategy for an empty or unrecognized name.
+func newBlockBuildingStrategy(name BuilderStrategyName, chain *core.BlockChain, chainConfig *params.ChainConfig, blacklist map[common.Address]struct{}, interrupt *int32) BlockBuildingStrategy {
+	chainData := chainData{chainConfig, chain, blacklist}
+	switch name {
+	case StrategyProfitBacktracking:
+		return &profitBacktrackingStrategy{chainData: chainData, interrupt: interrupt}
+	case StrategyRace:
+		return &raceStrategy{
+			strategies: []BlockBuildingStrategy{
+				&greedyStrategy{chainData: chainData, interrupt: interrupt},
+				&profitBacktrackingStrategy{chainData: chainData, interrupt: interrupt},
+			},
+		}
+	default:
+		return &greedyStrategy{chainData: chainData, interrupt: interrupt}
+	}
+}
+
+// greedyStrategy adapts the existing greedyBuilder to BlockBuildingStrategy.
+type greedyStrategy struct {
+	chainData chainData
+	interrupt *int32
+}
+
+func (s *greedyStrategy) BuildBlock(env *environment, bundles []types.SimulatedBundle, txs map[common.Address]types.Transactions) (*environment, []types.SimulatedBundle) {
+	b := &greedyBuilder{inputEnvironment: env, chainData: s.chainData, interrupt: s.interrupt}
+	return b.buildBlock(bundles, txs)
+}
+
+// profitBacktrackingStrategy orders bundles the same way greedyBuilder does,
+// but snapshots the state before committing each bundle and rolls it back if
+// the bundle's realized coinbase delta fell short of what it simulated to,
+// i.e. it silently became unprofitable because of an earlier inclusion.
+type profitBacktrackingStrategy struct {
+	chainData chainData
+	interrupt *int32
+}
+
+func (s *profitBacktrackingStrategy) BuildBlock(env *environment, simBundles []types.SimulatedBundle, txs map[common.Address]types.Transactions) (*environment, []types.SimulatedBundle) {
+	simBundles, _ = AnalyzeBundleConflicts(simBundles, env, s.chainData)
+	orders := types.NewTransactionsByPriceAndNonce(env.signer, txs, simBundles, env.header.BaseFee)
+	return s.mergeOrdersIntoEnvDiff(env.copy(), orders)
+}
+
+// mergeOrdersIntoEnvDiff walks orders, batching mempool txs into one shared
+// environmentDiff the same way greedyBuilder does, and only forking a
+// throwaway diff around a bundle's trial commit. A bundle's effect is only
+// folded back into the running environment once its realized coinbase delta
+// is confirmed to still meet what it simulated to; otherwise the forked diff
+// is simply discarded and the shared diff is untouched.
+//
+// This is deliberately not "commit the bundle into the shared diff, then roll
+// back its StateDB snapshot on failure": an environmentDiff also accumulates
+// its own tx/receipt/gas-pool bookkeeping as it commits, and a bare state
+// rollback doesn't undo that half, leaving the diff's bookkeeping referencing
+// a bundle that was never actually kept.
+func (s *profitBacktrackingStrategy) mergeOrdersIntoEnvDiff(current *environment, orders *types.TransactionsByPriceAndNonce) (*environment, []types.SimulatedBundle) {
+	usedBundles := []types.SimulatedBundle{}
+	diff := newEnvironmentDiff(current)
+
+	// flush folds diff's accumulated tx commits back into current and starts
+	// a fresh diff from the result, so current is up to date whenever a
+	// bundle trial (or the final return) needs to fork or report off of it.
+	flush := func() {
+		diff.applyToBaseEnv()
+		current = diff.baseEnvironment
+		diff = newEnvironmentDiff(current)
+	}
+
+	for {
+		if s.interrupt != nil && atomic.LoadInt32(s.interrupt) != 0 {
+			break
+		}
+
+		order := orders.Peek()
+		if order == nil {
+			break
+		}
+
+		if tx := order.Tx(); tx != nil {
+			_, skip, err := diff.commitTx(tx, s.chainData)
+			switch skip {
+			case shiftTx:
+				orders.Shift()
+			case popTx:
+				orders.Pop()
+			}
+			if err != nil {
+				log.Trace("could not apply tx", "hash", tx.Hash(), "err", err)
+			}
+			continue
+		}
+
+		bundle := order.Bundle()
+		if bundle == nil {
+			orders.Pop()
+			continue
+		}
+		orders.Pop()
+
+		// Fold any txs batched since the last bundle before forking a trial
+		// for this one, so the trial (and current, if the bundle sticks) both
+		// see them.
+		flush()
+
+		coinbaseBefore := current.state.GetBalance(current.coinbase)
+		// Fork onto a copy of current, matching the newEnvironmentDiff(env.copy())
+		// convention used by every top-level caller above: applyToBaseEnv
+		// mutates its base in place, and current must stay a valid fallback
+		// for as long as this bundle's profitability is still undecided.
+		trialDiff := newEnvironmentDiff(current.copy())
+		if err := trialDiff.commitBundle(bundle, s.chainData, s.interrupt); err != nil {
+			log.Trace("could not apply bundle", "bundle", bundle.OriginalBundle.Hash, "err", err)
+			continue
+		}
+
+		// Check profitability against the trial diff's own working state,
+		// before folding anything back into the shared environment: if it
+		// falls short, trialDiff is simply dropped and current is untouched.
+		coinbaseAfter := trialDiff.state.GetBalance(trialDiff.baseEnvironment.coinbase)
+		realizedProfit := new(big.Int).Sub(coinbaseAfter, coinbaseBefore)
+		if realizedProfit.Cmp(bundle.TotalEth) < 0 {
+			log.Trace("bundle became unprofitable after earlier inclusions, discarding",
+				"bundle", bundle.OriginalBundle.Hash, "simulatedEth", bundle.TotalEth, "realizedEth", realizedProfit)
+			continue
+		}
+
+		trialDiff.applyToBaseEnv()
+		current = trialDiff.baseEnvironment
+		diff = newEnvironmentDiff(current)
+		log.Info("Included bundle", "bundleEGP", bundle.MevGasPrice.String(),
+			"gasUsed", bundle.TotalGasUsed,
+			"ethToCoinbase", ethIntToFloat(realizedProfit),
+			"hash", bundle.OriginalBundle.Hash.Hex())
+		usedBundles = append(usedBundles, *bundle)
+	}
+
+	flush()
+	return current, usedBundles
+}
+
+// raceStrategy runs every configured strategy concurrently and keeps the
+// block with the highest coinbase balance.
+type raceStrategy struct {
+	strategies []BlockBuildingStrategy
+}
+
+func (s *raceStrategy) BuildBlock(env *environment, bundles []types.SimulatedBundle, txs map[common.Address]types.Transactions) (*environment, []types.SimulatedBundle) {
+	type result struct {
+		env     *environment
+		bundles []types.SimulatedBundle
+	}
+
+	results := make([]result, len(s.strategies))
+	var wg sync.WaitGroup
+	for i, strat := range s.strategies {
+		i, strat := i, strat
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e, b := strat.BuildBlock(env, bundles, txs)
+			results[i] = result{env: e, bundles: b}
+		}()
+	}
+	wg.Wait()
+
+	var best *result
+	for i := range results {
+		r := &results[i]
+		if r.env == nil {
+			continue
+		}
+		if best == nil || r.env.state.GetBalance(r.env.coinbase).Cmp(best.env.state.GetBalance(best.env.coinbase)) > 0 {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, nil
+	}
+	return best.env, best.bundles
+}
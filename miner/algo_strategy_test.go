@@ -0,0 +1,53 @@
+package miner
+
+import "testing"
+
+// TestNewBlockBuildingStrategySelection covers the wiring
+// newBlockBuildingStrategy is responsible for: picking the right
+// BlockBuildingStrategy implementation for a configured name, and falling
+// back to greedy for an unrecognized one. The strategies' actual
+// bundle-merging/rollback behavior needs a live environment/chain fixture to
+// exercise and isn't covered here.
+func TestNewBlockBuildingStrategySelection(t *testing.T) {
+	tests := []struct {
+		name     BuilderStrategyName
+		wantType string
+	}{
+		{"", "*miner.greedyStrategy"},
+		{StrategyGreedy, "*miner.greedyStrategy"},
+		{StrategyProfitBacktracking, "*miner.profitBacktrackingStrategy"},
+		{StrategyRace, "*miner.raceStrategy"},
+		{"not-a-real-strategy", "*miner.greedyStrategy"},
+	}
+
+	for _, tt := range tests {
+		strategy := newBlockBuildingStrategy(tt.name, nil, nil, nil, nil)
+		if got := typeName(strategy); got != tt.wantType {
+			t.Errorf("newBlockBuildingStrategy(%q) = %s, want %s", tt.name, got, tt.wantType)
+		}
+	}
+}
+
+func TestNewBlockBuildingStrategyRaceRunsBothStrategies(t *testing.T) {
+	strategy := newBlockBuildingStrategy(StrategyRace, nil, nil, nil, nil)
+	race, ok := strategy.(*raceStrategy)
+	if !ok {
+		t.Fatalf("expected *raceStrategy, got %T", strategy)
+	}
+	if len(race.strategies) != 2 {
+		t.Fatalf("expected race to run 2 strategies, got %d", len(race.strategies))
+	}
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case *greedyStrategy:
+		return "*miner.greedyStrategy"
+	case *profitBacktrackingStrategy:
+		return "*miner.profitBacktrackingStrategy"
+	case *raceStrategy:
+		return "*miner.raceStrategy"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,105 @@
+package miner
+
+import (
+	"fmt"
+	"math/big"
+
+	mapset "github.com/deckarep/golang-set"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/misc/eip1559"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// BuildBlockForBacktest drives the same bundle-merging logic the live
+// worker uses (via BlockBuildingStrategy), but against a historical parent
+// block instead of the chain head. It exists so the backtest package can
+// replay payload building without reaching into miner's unexported
+// environment/environmentDiff internals.
+//
+// conflictDropped is the subset of simBundles that AnalyzeBundleConflicts
+// excluded for interfering with a higher-value bundle, before the surviving
+// bundles were ever handed to the strategy; it's returned separately from
+// usedBundles so callers can tell "excluded as conflicting" apart from
+// "lost out during the strategy's own merge" when reporting why a bundle
+// didn't make it in.
+func BuildBlockForBacktest(
+	chain *core.BlockChain,
+	chainConfig *params.ChainConfig,
+	blacklist map[common.Address]struct{},
+	strategyName BuilderStrategyName,
+	parent *types.Header,
+	attrs *types.BuilderPayloadAttributes,
+	simBundles []types.SimulatedBundle,
+	txs map[common.Address]types.Transactions,
+) (block *types.Block, usedBundles []types.SimulatedBundle, conflictDropped []types.SimulatedBundle, profit *big.Int, err error) {
+	parentState, err := chain.StateAt(parent.Root)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("could not get state at parent %s: %w", parent.Hash(), err)
+	}
+
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, common.Big1),
+		GasLimit:   parent.GasLimit,
+		Time:       attrs.Timestamp,
+		Coinbase:   attrs.SuggestedFeeRecipient,
+		Extra:      []byte{},
+	}
+	if chainConfig.IsLondon(header.Number) {
+		header.BaseFee = eip1559.CalcBaseFee(chainConfig, parent)
+	}
+
+	// Filled in the same way makeEnv does for the live worker: env.copy() and
+	// newEnvironmentDiff both dereference gasPool and clone ancestors/family
+	// unconditionally, so those can't be left at their zero value here.
+	env := &environment{
+		signer:    types.MakeSigner(chainConfig, header.Number, header.Time),
+		state:     parentState,
+		ancestors: mapset.NewSet(),
+		family:    mapset.NewSet(),
+		header:    header,
+		coinbase:  header.Coinbase,
+		gasPool:   new(core.GasPool).AddGas(header.GasLimit),
+	}
+
+	cd := chainData{chainConfig, chain, blacklist}
+	conflictFiltered, _ := AnalyzeBundleConflicts(simBundles, env, cd)
+	conflictDropped = DroppedBundles(simBundles, conflictFiltered)
+
+	interrupt := new(int32)
+	strategy := newBlockBuildingStrategy(strategyName, chain, chainConfig, blacklist, interrupt)
+
+	coinbaseBefore := env.state.GetBalance(env.coinbase)
+	resultEnv, usedBundles := strategy.BuildBlock(env, conflictFiltered, txs)
+	if resultEnv == nil {
+		return nil, nil, nil, nil, fmt.Errorf("strategy %s produced no block for parent %s", strategyName, parent.Hash())
+	}
+	coinbaseAfter := resultEnv.state.GetBalance(resultEnv.coinbase)
+	profit = new(big.Int).Sub(coinbaseAfter, coinbaseBefore)
+
+	block, err = chain.Engine().FinalizeAndAssemble(chain, resultEnv.header, resultEnv.state, resultEnv.txs, nil, resultEnv.receipts, nil)
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("could not finalize backtest block: %w", err)
+	}
+
+	return block, usedBundles, conflictDropped, profit, nil
+}
+
+// DroppedBundles returns the subset of simBundles that isn't present in
+// usedBundles, for reporting purposes.
+func DroppedBundles(simBundles, usedBundles []types.SimulatedBundle) []types.SimulatedBundle {
+	used := make(map[common.Hash]struct{}, len(usedBundles))
+	for _, b := range usedBundles {
+		used[b.OriginalBundle.Hash] = struct{}{}
+	}
+
+	var dropped []types.SimulatedBundle
+	for _, b := range simBundles {
+		if _, ok := used[b.OriginalBundle.Hash]; !ok {
+			dropped = append(dropped, b)
+		}
+	}
+	return dropped
+}
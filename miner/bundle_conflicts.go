@@ -0,0 +1,289 @@
+package miner
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// branchAndBoundNodeLimit is the largest conflict graph selectNonConflictingBundles
+// will solve exactly. Above this it falls back to a greedy pass, since the
+// branch-and-bound search is exponential in the worst case.
+const branchAndBoundNodeLimit = 20
+
+// BundleAccessSet is the read-set and write-set a bundle touched while being
+// simulated, keyed by contract address and storage slot. Two bundles
+// conflict if one's write-set intersects the other's read-set (or
+// write-set): running both in the same block risks one invalidating the
+// other's simulated outcome.
+type BundleAccessSet struct {
+	Reads  map[common.Address]map[common.Hash]struct{}
+	Writes map[common.Address]map[common.Hash]struct{}
+}
+
+func newBundleAccessSet() *BundleAccessSet {
+	return &BundleAccessSet{
+		Reads:  make(map[common.Address]map[common.Hash]struct{}),
+		Writes: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (a *BundleAccessSet) addRead(addr common.Address, slot common.Hash) {
+	if a.Reads[addr] == nil {
+		a.Reads[addr] = make(map[common.Hash]struct{})
+	}
+	a.Reads[addr][slot] = struct{}{}
+}
+
+func (a *BundleAccessSet) addWrite(addr common.Address, slot common.Hash) {
+	if a.Writes[addr] == nil {
+		a.Writes[addr] = make(map[common.Hash]struct{})
+	}
+	a.Writes[addr][slot] = struct{}{}
+}
+
+func (a *BundleAccessSet) conflictsWith(b *BundleAccessSet) bool {
+	return accessSetsIntersect(a.Writes, b.Reads) ||
+		accessSetsIntersect(b.Writes, a.Reads) ||
+		accessSetsIntersect(a.Writes, b.Writes)
+}
+
+func accessSetsIntersect(a, b map[common.Address]map[common.Hash]struct{}) bool {
+	for addr, slots := range a {
+		otherSlots, ok := b[addr]
+		if !ok {
+			continue
+		}
+		for slot := range slots {
+			if _, ok := otherSlots[slot]; ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// bundleConflictTracer is a minimal vm.EVMLogger that records every SLOAD as
+// a read and every SSTORE as a write, attributing them to whichever bundle
+// is currently being simulated.
+type bundleConflictTracer struct {
+	access *BundleAccessSet
+}
+
+func newBundleConflictTracer() *bundleConflictTracer {
+	return &bundleConflictTracer{access: newBundleAccessSet()}
+}
+
+func (t *bundleConflictTracer) CaptureTxStart(gasLimit uint64) {}
+func (t *bundleConflictTracer) CaptureTxEnd(restGas uint64)    {}
+
+func (t *bundleConflictTracer) CaptureStart(env *vm.EVM, from, to common.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+func (t *bundleConflictTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {}
+
+func (t *bundleConflictTracer) CaptureEnter(typ vm.OpCode, from, to common.Address, input []byte, gas uint64, value *big.Int) {
+}
+func (t *bundleConflictTracer) CaptureExit(output []byte, gasUsed uint64, err error) {}
+
+func (t *bundleConflictTracer) CaptureFault(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, depth int, err error) {
+}
+
+func (t *bundleConflictTracer) CaptureState(pc uint64, op vm.OpCode, gas, cost uint64, scope *vm.ScopeContext, rData []byte, depth int, err error) {
+	if scope.Stack.Len() < 1 {
+		return
+	}
+	addr := scope.Contract.Address()
+	slot := common.Hash(scope.Stack.Back(0).Bytes32())
+
+	switch op {
+	case vm.SLOAD:
+		t.access.addRead(addr, slot)
+	case vm.SSTORE:
+		t.access.addWrite(addr, slot)
+	}
+}
+
+// CaptureBundleAccessSet replays a bundle's transactions against a copy of
+// env's state with a conflict tracer attached, to recover the read-set and
+// write-set it touches. It's a replay rather than a reuse of whatever
+// simulation produced the SimulatedBundle in the first place, so conflict
+// analysis doesn't depend on that pipeline threading a tracer through.
+// A bundle tx that fails to apply just truncates its access set at that
+// point, consistent with it also failing during the real merge.
+func CaptureBundleAccessSet(bundle types.SimulatedBundle, env *environment, chainData chainData) *BundleAccessSet {
+	tracer := newBundleConflictTracer()
+	statedb := env.state.Copy()
+	gasPool := new(core.GasPool).AddGas(env.header.GasLimit)
+	var usedGas uint64
+
+	for _, tx := range bundle.OriginalBundle.Txs {
+		vmConfig := vm.Config{Tracer: tracer}
+		_, err := core.ApplyTransaction(chainData.chainConfig, chainData.chain, &env.header.Coinbase, gasPool, statedb, env.header, tx, &usedGas, vmConfig)
+		if err != nil {
+			log.Trace("could not replay bundle tx for conflict analysis", "bundle", bundle.OriginalBundle.Hash, "tx", tx.Hash(), "err", err)
+			break
+		}
+	}
+	return tracer.access
+}
+
+// AnalyzeBundleConflicts captures each bundle's access set and returns the
+// highest-value set of bundles that don't conflict with each other, plus how
+// many bundles were excluded as conflicting. It's meant to run once per
+// block, before the surviving bundles are merged via
+// types.NewTransactionsByPriceAndNonce.
+func AnalyzeBundleConflicts(simBundles []types.SimulatedBundle, env *environment, chainData chainData) ([]types.SimulatedBundle, int) {
+	if len(simBundles) == 0 {
+		return simBundles, 0
+	}
+
+	accessSets := make([]*BundleAccessSet, len(simBundles))
+	for i, bundle := range simBundles {
+		accessSets[i] = CaptureBundleAccessSet(bundle, env, chainData)
+	}
+
+	return selectNonConflictingBundles(simBundles, accessSets)
+}
+
+func selectNonConflictingBundles(bundles []types.SimulatedBundle, accessSets []*BundleAccessSet) ([]types.SimulatedBundle, int) {
+	n := len(bundles)
+	conflicts := make([][]bool, n)
+	for i := range conflicts {
+		conflicts[i] = make([]bool, n)
+	}
+
+	conflictEdges := 0
+	for i := 0; i < n; i++ {
+		for j := i + 1; j < n; j++ {
+			if accessSets[i].conflictsWith(accessSets[j]) {
+				conflicts[i][j] = true
+				conflicts[j][i] = true
+				conflictEdges++
+			}
+		}
+	}
+
+	var chosen []int
+	if n <= branchAndBoundNodeLimit {
+		chosen = mwisBranchAndBound(bundles, conflicts)
+	} else {
+		chosen = mwisGreedy(bundles, conflicts)
+	}
+
+	selected := make([]types.SimulatedBundle, 0, len(chosen))
+	for _, i := range chosen {
+		selected = append(selected, bundles[i])
+	}
+
+	conflictsAvoided := n - len(selected)
+	if conflictEdges > 0 {
+		log.Info("bundle conflict analysis", "bundles", n, "conflictEdges", conflictEdges, "conflictsAvoided", conflictsAvoided)
+	}
+	return selected, conflictsAvoided
+}
+
+// mwisGreedy picks bundles highest-MevGasPrice-first, skipping any bundle
+// that conflicts with one already chosen. Not optimal, but cheap for large
+// bundle sets.
+func mwisGreedy(bundles []types.SimulatedBundle, conflicts [][]bool) []int {
+	order := make([]int, len(bundles))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bundles[order[a]].MevGasPrice.Cmp(bundles[order[b]].MevGasPrice) > 0
+	})
+
+	excluded := make([]bool, len(bundles))
+	chosen := []int{}
+	for _, i := range order {
+		if excluded[i] {
+			continue
+		}
+		chosen = append(chosen, i)
+		for j, conflict := range conflicts[i] {
+			if conflict {
+				excluded[j] = true
+			}
+		}
+	}
+	return chosen
+}
+
+// mwisBranchAndBound solves maximum-weight independent set exactly by
+// including/excluding each bundle in turn. Only used for small graphs; the
+// search space is 2^n in the worst case, but two things keep it cheap in
+// practice: bundles are visited highest-weight-first, so a good candidate
+// solution is found early, and at each node the best possible weight the
+// remaining bundles could still add (their suffix sum, ignoring conflicts)
+// bounds whether continuing that branch could ever beat the best found so
+// far. A branch that can't is cut immediately instead of being enumerated.
+func mwisBranchAndBound(bundles []types.SimulatedBundle, conflicts [][]bool) []int {
+	n := len(bundles)
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return bundles[order[a]].MevGasPrice.Cmp(bundles[order[b]].MevGasPrice) > 0
+	})
+
+	// suffixWeight[idx] is the total weight of order[idx:], i.e. an upper
+	// bound on what including every remaining bundle (conflicts aside) could
+	// still add to the current branch's weight.
+	suffixWeight := make([]*big.Int, n+1)
+	suffixWeight[n] = new(big.Int)
+	for idx := n - 1; idx >= 0; idx-- {
+		suffixWeight[idx] = new(big.Int).Add(suffixWeight[idx+1], bundles[order[idx]].MevGasPrice)
+	}
+
+	var best []int
+	// Start below any reachable weight so a set whose total is zero (e.g. all
+	// zero-MevGasPrice bundles) is still recorded rather than dropped.
+	bestWeight := big.NewInt(-1)
+
+	var search func(idx int, current []int, currentWeight *big.Int, excluded []bool)
+	search = func(idx int, current []int, currentWeight *big.Int, excluded []bool) {
+		if idx == n {
+			if currentWeight.Cmp(bestWeight) > 0 {
+				bestWeight = currentWeight
+				best = append([]int{}, current...)
+			}
+			return
+		}
+
+		// Even including everything left can't beat the best found so far:
+		// no point exploring either branch below this node.
+		bound := new(big.Int).Add(currentWeight, suffixWeight[idx])
+		if bound.Cmp(bestWeight) <= 0 {
+			return
+		}
+
+		i := order[idx]
+
+		// Include bundle i first, if nothing already chosen conflicts with
+		// it: trying the heavier option first tends to raise bestWeight
+		// quickly, which is what makes the bound above actually prune.
+		if !excluded[i] {
+			nextExcluded := append([]bool{}, excluded...)
+			for j, conflict := range conflicts[i] {
+				if conflict {
+					nextExcluded[j] = true
+				}
+			}
+			search(idx+1, append(current, i), new(big.Int).Add(currentWeight, bundles[i].MevGasPrice), nextExcluded)
+		}
+
+		// Exclude bundle i from the set.
+		search(idx+1, current, currentWeight, excluded)
+	}
+	search(0, nil, new(big.Int), make([]bool, n))
+
+	return best
+}
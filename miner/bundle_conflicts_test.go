@@ -0,0 +1,106 @@
+package miner
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func conflictBundle(hash byte, mevGasPrice int64) types.SimulatedBundle {
+	var h common.Hash
+	h[0] = hash
+	return types.SimulatedBundle{
+		OriginalBundle: types.MevBundle{Hash: h},
+		MevGasPrice:    big.NewInt(mevGasPrice),
+		TotalEth:       big.NewInt(mevGasPrice),
+	}
+}
+
+// accessSetsFor builds one BundleAccessSet per bundle such that bundles i and
+// j conflict exactly when conflicts[i][j] is set: for each conflicting pair,
+// the lower-indexed bundle writes a storage slot unique to that pair and the
+// other bundle reads it.
+func accessSetsFor(conflicts [][]bool) []*BundleAccessSet {
+	sets := make([]*BundleAccessSet, len(conflicts))
+	for i := range sets {
+		sets[i] = newBundleAccessSet()
+	}
+	for i := range conflicts {
+		for j := i + 1; j < len(conflicts); j++ {
+			if conflicts[i][j] {
+				slot := common.BigToHash(big.NewInt(int64(i*len(conflicts) + j)))
+				sets[i].addWrite(common.Address{}, slot)
+				sets[j].addRead(common.Address{}, slot)
+			}
+		}
+	}
+	return sets
+}
+
+func TestSelectNonConflictingBundlesDropsTheLowerValueOfAConflictingPair(t *testing.T) {
+	bundles := []types.SimulatedBundle{
+		conflictBundle(1, 100),
+		conflictBundle(2, 50),
+	}
+	conflicts := [][]bool{
+		{false, true},
+		{true, false},
+	}
+
+	selected, dropped := selectNonConflictingBundles(bundles, accessSetsFor(conflicts))
+	if dropped != 1 {
+		t.Fatalf("expected 1 bundle dropped as conflicting, got %d", dropped)
+	}
+	if len(selected) != 1 || selected[0].OriginalBundle.Hash != bundles[0].OriginalBundle.Hash {
+		t.Fatalf("expected only the higher-value bundle to survive, got %+v", selected)
+	}
+}
+
+func TestSelectNonConflictingBundlesKeepsIndependentBundles(t *testing.T) {
+	bundles := []types.SimulatedBundle{
+		conflictBundle(1, 100),
+		conflictBundle(2, 50),
+	}
+	conflicts := [][]bool{
+		{false, false},
+		{false, false},
+	}
+
+	selected, dropped := selectNonConflictingBundles(bundles, accessSetsFor(conflicts))
+	if dropped != 0 {
+		t.Fatalf("expected no bundles dropped when none conflict, got %d", dropped)
+	}
+	if len(selected) != 2 {
+		t.Fatalf("expected both bundles to survive, got %+v", selected)
+	}
+}
+
+// TestMwisBranchAndBoundAndGreedyAgreeOnASmallConflictGraph exercises both
+// selection paths (branch-and-bound, used up to branchAndBoundNodeLimit, and
+// the greedy fallback above it) against the same conflict graph: a 3-cycle
+// where no two bundles can both be kept, so the optimum is exactly one
+// bundle - the highest-value one.
+func TestMwisBranchAndBoundAndGreedyAgreeOnASmallConflictGraph(t *testing.T) {
+	bundles := []types.SimulatedBundle{
+		conflictBundle(1, 30),
+		conflictBundle(2, 50),
+		conflictBundle(3, 10),
+	}
+	conflicts := [][]bool{
+		{false, true, true},
+		{true, false, true},
+		{true, true, false},
+	}
+
+	for name, pick := range map[string]func([]types.SimulatedBundle, [][]bool) []int{
+		"branchAndBound": mwisBranchAndBound,
+		"greedy":         mwisGreedy,
+	} {
+		chosen := pick(bundles, conflicts)
+		if len(chosen) != 1 || bundles[chosen[0]].MevGasPrice.Cmp(big.NewInt(50)) != 0 {
+			t.Errorf("%s: expected only the highest-value bundle to be chosen, got indices %v", name, chosen)
+		}
+	}
+}